@@ -0,0 +1,227 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priorities
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/algorithm/priorities/util"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
+	"k8s.io/kubernetes/pkg/scheduler/schedulercache"
+)
+
+const (
+	metricsDecisionServed  = "served"
+	metricsDecisionStale   = "stale"
+	metricsDecisionMissing = "missing"
+)
+
+var loadAwareMetricsDecisions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "scheduler_load_aware_requested_to_capacity_ratio_metrics_decisions_total",
+		Help: "Number of times LoadAwareRequestedToCapacityRatioPriority served, fell back due to stale metrics, or fell back due to missing metrics, by decision.",
+	},
+	[]string{"decision"},
+)
+
+func init() {
+	prometheus.MustRegister(loadAwareMetricsDecisions)
+}
+
+// NodeMetrics is a point-in-time observed utilization reading for a node.
+type NodeMetrics struct {
+	// Usage is the resource actually consumed on the node at Timestamp.
+	Usage *schedulercache.Resource
+	// Timestamp is when Usage was sampled.
+	Timestamp time.Time
+}
+
+// NodeMetricsProvider supplies real observed node utilization, e.g. backed by
+// metrics-server or a custom informer cache. ok is false when no metric is available for
+// nodeName.
+type NodeMetricsProvider interface {
+	GetNodeMetrics(nodeName string) (metrics *NodeMetrics, ok bool)
+}
+
+type smoothedNodeUsage struct {
+	usage     *schedulercache.Resource
+	timestamp time.Time
+}
+
+// LoadAwareRequestedToCapacityRatioPriority is a variant of RequestedToCapacityRatio that
+// scores nodes from real observed utilization, pulled from a NodeMetricsProvider and
+// exponentially smoothed over smoothingWindow to avoid reacting to transient spikes,
+// instead of from pod requests. score = shape(smoothedUsed/allocable * 100).
+// A node whose metrics are missing, or older than staleThreshold, falls back to
+// request-based scoring using the same resources.
+type LoadAwareRequestedToCapacityRatioPriority struct {
+	Name string
+
+	resources           []ResourceSpec
+	rawScoringFunctions map[v1.ResourceName]func(int64) int64
+	totalWeight         int64
+
+	metricsProvider NodeMetricsProvider
+	smoothingWindow time.Duration
+	staleThreshold  time.Duration
+	fallbackScorer  func(requested, allocable *schedulercache.Resource, includeVolumes bool, requestedVolumes int, allocatableVolumes int) int64
+
+	now func() time.Time
+
+	mu       sync.Mutex
+	smoothed map[string]smoothedNodeUsage
+}
+
+// NewLoadAwareRequestedToCapacityRatioPriority creates a LoadAwareRequestedToCapacityRatioPriority
+// scoring resources from utilization reported by metricsProvider. resources is validated
+// exactly as RequestedToCapacityRatioResourceAllocationPriority validates it.
+// smoothingWindow and staleThreshold must be positive.
+func NewLoadAwareRequestedToCapacityRatioPriority(resources []ResourceSpec, metricsProvider NodeMetricsProvider, smoothingWindow, staleThreshold time.Duration) (*LoadAwareRequestedToCapacityRatioPriority, error) {
+	if err := validateResourceSpecs(resources); err != nil {
+		return nil, err
+	}
+	if metricsProvider == nil {
+		return nil, fmt.Errorf("metricsProvider must not be nil")
+	}
+	if smoothingWindow <= 0 {
+		return nil, fmt.Errorf("smoothingWindow must be positive, got %s", smoothingWindow)
+	}
+	if staleThreshold <= 0 {
+		return nil, fmt.Errorf("staleThreshold must be positive, got %s", staleThreshold)
+	}
+
+	rawScoringFunctions := make(map[v1.ResourceName]func(int64) int64, len(resources))
+	var totalWeight int64
+	for _, resource := range resources {
+		rawScoringFunctions[resource.Name] = buildBrokenLinearFunction(resource.Shape)
+		totalWeight += resource.Weight
+	}
+
+	return &LoadAwareRequestedToCapacityRatioPriority{
+		Name:                "LoadAwareRequestedToCapacityRatioPriority",
+		resources:           resources,
+		rawScoringFunctions: rawScoringFunctions,
+		totalWeight:         totalWeight,
+		metricsProvider:     metricsProvider,
+		smoothingWindow:     smoothingWindow,
+		staleThreshold:      staleThreshold,
+		fallbackScorer:      buildRequestedToCapacityRatioScorerFunction(resources),
+		now:                 time.Now,
+		smoothed:            make(map[string]smoothedNodeUsage),
+	}, nil
+}
+
+// PriorityMap scores pod against node from node's smoothed observed utilization, falling
+// back to request-based scoring when no fresh metric is available for node.
+func (l *LoadAwareRequestedToCapacityRatioPriority) PriorityMap(pod *v1.Pod, meta interface{}, nodeInfo *schedulercache.NodeInfo) (schedulerapi.HostPriority, error) {
+	node := nodeInfo.Node()
+	if node == nil {
+		return schedulerapi.HostPriority{}, fmt.Errorf("node not found")
+	}
+
+	allocatable := nodeInfo.AllocatableResource()
+
+	usage, decision := l.resolveUsage(node.Name)
+	loadAwareMetricsDecisions.WithLabelValues(decision).Inc()
+
+	var score int64
+	if usage != nil {
+		score = l.scoreFromUsage(usage, &allocatable)
+	} else {
+		requested := util.GetResourceRequest(pod)
+		score = l.fallbackScorer(requested, &allocatable, len(pod.Spec.Volumes) > 0, len(pod.Spec.Volumes), len(node.Status.VolumesAttached))
+	}
+
+	return schedulerapi.HostPriority{Host: node.Name, Score: int(score)}, nil
+}
+
+// resolveUsage returns nodeName's smoothed observed utilization and "served", or nil and
+// "missing"/"stale" when no usable metric is available.
+func (l *LoadAwareRequestedToCapacityRatioPriority) resolveUsage(nodeName string) (*schedulercache.Resource, string) {
+	metrics, ok := l.metricsProvider.GetNodeMetrics(nodeName)
+	if !ok {
+		return nil, metricsDecisionMissing
+	}
+	if l.now().Sub(metrics.Timestamp) > l.staleThreshold {
+		return nil, metricsDecisionStale
+	}
+	return l.smooth(nodeName, metrics), metricsDecisionServed
+}
+
+// smooth folds metrics into nodeName's running exponentially-weighted average, using
+// smoothingWindow as the time constant, and returns the updated smoothed usage.
+func (l *LoadAwareRequestedToCapacityRatioPriority) smooth(nodeName string, metrics *NodeMetrics) *schedulercache.Resource {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	previous, ok := l.smoothed[nodeName]
+	if !ok {
+		l.smoothed[nodeName] = smoothedNodeUsage{usage: metrics.Usage, timestamp: metrics.Timestamp}
+		return metrics.Usage
+	}
+	if !metrics.Timestamp.After(previous.timestamp) {
+		// A sample no newer than the last one folded in carries nothing new.
+		return previous.usage
+	}
+
+	alpha := 1 - math.Exp(-metrics.Timestamp.Sub(previous.timestamp).Seconds()/l.smoothingWindow.Seconds())
+
+	smoothed := &schedulercache.Resource{
+		MilliCPU:        exponentialAverage(previous.usage.MilliCPU, metrics.Usage.MilliCPU, alpha),
+		Memory:          exponentialAverage(previous.usage.Memory, metrics.Usage.Memory, alpha),
+		ScalarResources: make(map[v1.ResourceName]int64, len(metrics.Usage.ScalarResources)),
+	}
+	for name, value := range metrics.Usage.ScalarResources {
+		smoothed.ScalarResources[name] = exponentialAverage(previous.usage.ScalarResources[name], value, alpha)
+	}
+
+	l.smoothed[nodeName] = smoothedNodeUsage{usage: smoothed, timestamp: metrics.Timestamp}
+	return smoothed
+}
+
+// exponentialAverage blends previous and current with weight alpha on current.
+func exponentialAverage(previous, current int64, alpha float64) int64 {
+	return int64(alpha*float64(current) + (1-alpha)*float64(previous))
+}
+
+// scoreFromUsage computes the weighted sum, over l.resources, of shape(used/allocable *
+// 100), the same utilization percentage buildRequestedToCapacityRatioScorerFunction feeds
+// its shape functions, so a FunctionShape/preset means the same thing here as everywhere
+// else in this file. used > allocable is treated as full utilization.
+func (l *LoadAwareRequestedToCapacityRatioPriority) scoreFromUsage(usage, allocable *schedulercache.Resource) int64 {
+	var weightedScore int64
+	for _, resource := range l.resources {
+		capacity := requestedResourceValue(resource.Name, allocable)
+		used := requestedResourceValue(resource.Name, usage)
+
+		rawScoringFunction := l.rawScoringFunctions[resource.Name]
+		var utilizationScore int64
+		if capacity == 0 || used > capacity {
+			utilizationScore = rawScoringFunction(maxX)
+		} else {
+			utilizationScore = rawScoringFunction(maxX - (capacity-used)*maxX/capacity)
+		}
+
+		weightedScore += utilizationScore * resource.Weight
+	}
+	return weightedScore / l.totalWeight
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priorities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/schedulercache"
+)
+
+type fakeNodeMetricsProvider struct {
+	metrics map[string]*NodeMetrics
+}
+
+func (f *fakeNodeMetricsProvider) GetNodeMetrics(nodeName string) (*NodeMetrics, bool) {
+	metrics, ok := f.metrics[nodeName]
+	return metrics, ok
+}
+
+func TestNewLoadAwareRequestedToCapacityRatioPriorityValidation(t *testing.T) {
+	shape, _ := NewFunctionShape([]FunctionShapePoint{{0, 0}, {100, 10}})
+	resources := []ResourceSpec{{Name: v1.ResourceCPU, Weight: 1, Shape: shape}}
+	provider := &fakeNodeMetricsProvider{metrics: map[string]*NodeMetrics{}}
+
+	_, err := NewLoadAwareRequestedToCapacityRatioPriority(nil, provider, time.Minute, time.Minute)
+	assert.EqualError(t, err, "at least one resource must be specified")
+
+	_, err = NewLoadAwareRequestedToCapacityRatioPriority(resources, nil, time.Minute, time.Minute)
+	assert.EqualError(t, err, "metricsProvider must not be nil")
+
+	_, err = NewLoadAwareRequestedToCapacityRatioPriority(resources, provider, 0, time.Minute)
+	assert.EqualError(t, err, "smoothingWindow must be positive, got 0s")
+
+	_, err = NewLoadAwareRequestedToCapacityRatioPriority(resources, provider, time.Minute, 0)
+	assert.EqualError(t, err, "staleThreshold must be positive, got 0s")
+}
+
+func TestLoadAwareRequestedToCapacityRatioPriorityMap(t *testing.T) {
+	shape, _ := NewFunctionShape([]FunctionShapePoint{{0, 0}, {100, 10}})
+	resources := []ResourceSpec{
+		{Name: v1.ResourceCPU, Weight: 1, Shape: shape},
+		{Name: v1.ResourceMemory, Weight: 1, Shape: shape},
+	}
+
+	now := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	node := makeNode("node1", 4000, 8000)
+	pod := buildNUMATestPod(2000, 4000)
+	nodeNameToInfo := schedulercache.CreateNodeNameToInfoMap([]*v1.Pod{}, []*v1.Node{node})
+	nodeInfo := nodeNameToInfo["node1"]
+
+	tests := []struct {
+		name     string
+		metrics  map[string]*NodeMetrics
+		expected int
+	}{
+		{
+			name: "fresh metrics are served",
+			metrics: map[string]*NodeMetrics{
+				"node1": {
+					Usage:     &schedulercache.Resource{MilliCPU: 1000, Memory: 2000},
+					Timestamp: now,
+				},
+			},
+			expected: 2,
+		},
+		{
+			name: "stale metrics fall back to request-based scoring",
+			metrics: map[string]*NodeMetrics{
+				"node1": {
+					Usage:     &schedulercache.Resource{MilliCPU: 1000, Memory: 2000},
+					Timestamp: now.Add(-time.Hour),
+				},
+			},
+			expected: 5,
+		},
+		{
+			name:     "missing metrics fall back to request-based scoring",
+			metrics:  map[string]*NodeMetrics{},
+			expected: 5,
+		},
+		{
+			name: "usage over capacity clamps to full utilization",
+			metrics: map[string]*NodeMetrics{
+				"node1": {
+					Usage:     &schedulercache.Resource{MilliCPU: 6000, Memory: 2000},
+					Timestamp: now,
+				},
+			},
+			expected: 6,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := &fakeNodeMetricsProvider{metrics: test.metrics}
+			priority, err := NewLoadAwareRequestedToCapacityRatioPriority(resources, provider, time.Minute, 10*time.Minute)
+			assert.Nil(t, err)
+			priority.now = func() time.Time { return now }
+
+			hostPriority, err := priority.PriorityMap(pod, nil, nodeInfo)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, hostPriority.Score)
+		})
+	}
+}
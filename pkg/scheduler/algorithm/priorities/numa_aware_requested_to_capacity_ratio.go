@@ -0,0 +1,171 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priorities
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/algorithm/priorities/util"
+	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
+	"k8s.io/kubernetes/pkg/scheduler/schedulercache"
+)
+
+// NodeSocketTopologyAnnotationKey is the node annotation read by
+// NUMAAwareRequestedToCapacityRatioPriority to discover per-socket CPU/memory capacity
+// and usage. The value is a JSON array of NodeSocket entries, e.g.
+//   [{"id":"0","cpu_millis":32000,"memory_bytes":137438953472,"used_cpu_millis":4000,"used_memory_bytes":8589934592}]
+const NodeSocketTopologyAnnotationKey = "topology.node.kubernetes.io/sockets"
+
+// NodeSocket describes the capacity and current usage of a single NUMA socket, as
+// advertised by a node through the NodeSocketTopologyAnnotationKey annotation.
+type NodeSocket struct {
+	ID              string `json:"id"`
+	CPUMillis       int64  `json:"cpu_millis"`
+	MemoryBytes     int64  `json:"memory_bytes"`
+	UsedCPUMillis   int64  `json:"used_cpu_millis"`
+	UsedMemoryBytes int64  `json:"used_memory_bytes"`
+}
+
+// nodeSocketTopology parses NodeSocketTopologyAnnotationKey off node. It returns a nil
+// slice when the annotation is absent or fails to unmarshal, so callers fall back to
+// aggregate-node scoring for nodes that simply don't advertise topology; a malformed
+// annotation on one node should never break scoring of the rest of the cluster.
+func nodeSocketTopology(node *v1.Node) []NodeSocket {
+	raw, ok := node.Annotations[NodeSocketTopologyAnnotationKey]
+	if !ok {
+		return nil
+	}
+
+	var sockets []NodeSocket
+	if err := json.Unmarshal([]byte(raw), &sockets); err != nil {
+		return nil
+	}
+	return sockets
+}
+
+// NUMAAwareRequestedToCapacityRatioPriority is a bin-packing variant of
+// RequestedToCapacityRatio that, for nodes advertising per-socket topology via
+// NodeSocketTopologyAnnotationKey, scores the pod against the single best-fitting NUMA
+// socket rather than against the node's aggregate capacity. Nodes that don't advertise
+// topology, or on which no single socket can satisfy the pod's request, fall back to
+// aggregate-node scoring minus crossSocketPenalty so cross-socket placement is
+// deprioritized but still possible.
+type NUMAAwareRequestedToCapacityRatioPriority struct {
+	Name string
+
+	shape              FunctionShape
+	aggregateScorer    func(requested, allocable *schedulercache.Resource, includeVolumes bool, requestedVolumes int, allocatableVolumes int) int64
+	crossSocketPenalty int64
+}
+
+// NewNUMAAwareRequestedToCapacityRatioPriority creates a NUMAAwareRequestedToCapacityRatioPriority
+// using shape both to score individual sockets and, weighting CPU and memory equally, to
+// score aggregate node capacity when no socket fits. crossSocketPenalty is subtracted from
+// the aggregate score in that fallback case, and must not be negative. A bin-packing shape
+// (e.g. {0,0},{100,10}) makes tighter-fitting sockets score higher.
+func NewNUMAAwareRequestedToCapacityRatioPriority(shape FunctionShape, crossSocketPenalty int64) (*NUMAAwareRequestedToCapacityRatioPriority, error) {
+	if len(shape) == 0 {
+		return nil, fmt.Errorf("shape must not be empty")
+	}
+	if crossSocketPenalty < 0 {
+		return nil, fmt.Errorf("crossSocketPenalty must not be negative, got %d", crossSocketPenalty)
+	}
+
+	resources := []ResourceSpec{
+		{Name: v1.ResourceCPU, Weight: 1, Shape: shape},
+		{Name: v1.ResourceMemory, Weight: 1, Shape: shape},
+	}
+
+	return &NUMAAwareRequestedToCapacityRatioPriority{
+		Name:               "NUMAAwareRequestedToCapacityRatioPriority",
+		shape:              shape,
+		aggregateScorer:    buildRequestedToCapacityRatioScorerFunction(resources),
+		crossSocketPenalty: crossSocketPenalty,
+	}, nil
+}
+
+// PriorityMap scores pod against node: against the best-fitting single socket when node
+// advertises topology and at least one socket can satisfy the request, against aggregate
+// node capacity (minus crossSocketPenalty) when no socket fits but the node as a whole
+// does, or 0 when neither a socket nor the whole node can satisfy the request. The
+// aggregate check and score count the resources already requested on the node.
+func (n *NUMAAwareRequestedToCapacityRatioPriority) PriorityMap(pod *v1.Pod, meta interface{}, nodeInfo *schedulercache.NodeInfo) (schedulerapi.HostPriority, error) {
+	node := nodeInfo.Node()
+	if node == nil {
+		return schedulerapi.HostPriority{}, fmt.Errorf("node not found")
+	}
+
+	requested := util.GetResourceRequest(pod)
+	sockets := nodeSocketTopology(node)
+
+	if score, ok := n.bestSocketScore(requested, sockets); ok {
+		return schedulerapi.HostPriority{Host: node.Name, Score: int(score)}, nil
+	}
+
+	allocatable := nodeInfo.AllocatableResource()
+	nodeRequested := nodeInfo.RequestedResource()
+	totalRequested := &schedulercache.Resource{
+		MilliCPU: requested.MilliCPU + nodeRequested.MilliCPU,
+		Memory:   requested.Memory + nodeRequested.Memory,
+	}
+	if totalRequested.MilliCPU > allocatable.MilliCPU || totalRequested.Memory > allocatable.Memory {
+		return schedulerapi.HostPriority{Host: node.Name, Score: minY}, nil
+	}
+
+	score := n.aggregateScorer(totalRequested, &allocatable, false, 0, 0) - n.crossSocketPenalty
+	if score < minY {
+		score = minY
+	}
+
+	return schedulerapi.HostPriority{Host: node.Name, Score: int(score)}, nil
+}
+
+// bestSocketScore returns the highest broken-linear score, using n.shape, among sockets
+// that alone can satisfy requested (i.e. requested <= capacity - used for every scored
+// resource), and whether at least one such socket exists.
+func (n *NUMAAwareRequestedToCapacityRatioPriority) bestSocketScore(requested *schedulercache.Resource, sockets []NodeSocket) (int64, bool) {
+	rawScoringFunction := buildBrokenLinearFunction(n.shape)
+	resourceScore := func(totalRequested, capacity int64) int64 {
+		if capacity == 0 || totalRequested > capacity {
+			return rawScoringFunction(maxX)
+		}
+		return rawScoringFunction(maxX - (capacity-totalRequested)*maxX/capacity)
+	}
+
+	var best int64
+	found := false
+	for _, socket := range sockets {
+		if requested.MilliCPU > socket.CPUMillis-socket.UsedCPUMillis {
+			continue
+		}
+		if requested.Memory > socket.MemoryBytes-socket.UsedMemoryBytes {
+			continue
+		}
+
+		cpuScore := resourceScore(socket.UsedCPUMillis+requested.MilliCPU, socket.CPUMillis)
+		memoryScore := resourceScore(socket.UsedMemoryBytes+requested.Memory, socket.MemoryBytes)
+		score := (cpuScore + memoryScore) / 2
+
+		if !found || score > best {
+			best = score
+			found = true
+		}
+	}
+	return best, found
+}
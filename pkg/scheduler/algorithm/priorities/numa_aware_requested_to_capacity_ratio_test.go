@@ -0,0 +1,142 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package priorities
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/kubernetes/pkg/scheduler/schedulercache"
+)
+
+func buildNUMATestPod(cpuMillis, memoryBytes int64) *v1.Pod {
+	return &v1.Pod{Spec: v1.PodSpec{
+		Containers: []v1.Container{
+			{
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    *resource.NewMilliQuantity(cpuMillis, resource.DecimalSI),
+						v1.ResourceMemory: *resource.NewQuantity(memoryBytes, resource.DecimalSI),
+					},
+				},
+			},
+		},
+	}}
+}
+
+func annotateSocketTopology(node *v1.Node, sockets []NodeSocket) *v1.Node {
+	raw, err := json.Marshal(sockets)
+	if err != nil {
+		panic(err)
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[NodeSocketTopologyAnnotationKey] = string(raw)
+	return node
+}
+
+func TestNewNUMAAwareRequestedToCapacityRatioPriorityValidation(t *testing.T) {
+	mostRequestedShape, _ := NewFunctionShape([]FunctionShapePoint{{0, 0}, {100, 10}})
+
+	_, err := NewNUMAAwareRequestedToCapacityRatioPriority(FunctionShape{}, 0)
+	assert.EqualError(t, err, "shape must not be empty")
+
+	_, err = NewNUMAAwareRequestedToCapacityRatioPriority(mostRequestedShape, -1)
+	assert.EqualError(t, err, "crossSocketPenalty must not be negative, got -1")
+
+	priority, err := NewNUMAAwareRequestedToCapacityRatioPriority(mostRequestedShape, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "NUMAAwareRequestedToCapacityRatioPriority", priority.Name)
+}
+
+func TestNUMAAwareRequestedToCapacityRatioPriorityMap(t *testing.T) {
+	mostRequestedShape, _ := NewFunctionShape([]FunctionShapePoint{{0, 0}, {100, 10}})
+	priority, err := NewNUMAAwareRequestedToCapacityRatioPriority(mostRequestedShape, 2)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name     string
+		node     *v1.Node
+		pod      *v1.Pod
+		expected int
+	}{
+		{
+			name: "single socket, pod fits",
+			node: annotateSocketTopology(makeNode("node1", 8000, 16000), []NodeSocket{
+				{ID: "0", CPUMillis: 8000, MemoryBytes: 16000},
+			}),
+			pod:      buildNUMATestPod(4000, 8000),
+			expected: 5,
+		},
+		{
+			name: "multi socket, picks tightest fitting socket",
+			node: annotateSocketTopology(makeNode("node1", 16000, 32000), []NodeSocket{
+				{ID: "0", CPUMillis: 8000, MemoryBytes: 16000, UsedCPUMillis: 6000, UsedMemoryBytes: 12000},
+				{ID: "1", CPUMillis: 8000, MemoryBytes: 16000},
+			}),
+			pod:      buildNUMATestPod(2000, 4000),
+			expected: 10,
+		},
+		{
+			name: "pod larger than any single socket falls back to aggregate minus penalty",
+			node: annotateSocketTopology(makeNode("node1", 16000, 32000), []NodeSocket{
+				{ID: "0", CPUMillis: 8000, MemoryBytes: 16000},
+				{ID: "1", CPUMillis: 8000, MemoryBytes: 16000},
+			}),
+			pod:      buildNUMATestPod(12000, 8000),
+			expected: 2,
+		},
+		{
+			name:     "no topology annotation falls back to aggregate minus penalty",
+			node:     makeNode("node1", 8000, 16000),
+			pod:      buildNUMATestPod(4000, 8000),
+			expected: 3,
+		},
+		{
+			name: "malformed topology annotation falls back to aggregate minus penalty",
+			node: func() *v1.Node {
+				node := makeNode("node1", 8000, 16000)
+				node.Annotations = map[string]string{NodeSocketTopologyAnnotationKey: "not-json"}
+				return node
+			}(),
+			pod:      buildNUMATestPod(4000, 8000),
+			expected: 3,
+		},
+		{
+			name: "pod larger than the entire node scores 0",
+			node: annotateSocketTopology(makeNode("node1", 16000, 32000), []NodeSocket{
+				{ID: "0", CPUMillis: 8000, MemoryBytes: 16000},
+				{ID: "1", CPUMillis: 8000, MemoryBytes: 16000},
+			}),
+			pod:      buildNUMATestPod(20000, 8000),
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodeNameToInfo := schedulercache.CreateNodeNameToInfoMap([]*v1.Pod{}, []*v1.Node{test.node})
+			hostPriority, err := priority.PriorityMap(test.pod, nil, nodeNameToInfo[test.node.Name])
+			assert.Nil(t, err)
+			assert.Equal(t, test.expected, hostPriority.Score)
+		})
+	}
+}
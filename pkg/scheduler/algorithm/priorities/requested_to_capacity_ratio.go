@@ -17,8 +17,12 @@ limitations under the License.
 package priorities
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 
+	"k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/algorithm/priorities/util"
 	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
 	"k8s.io/kubernetes/pkg/scheduler/schedulercache"
 )
@@ -82,24 +86,143 @@ func NewFunctionShape(points []FunctionShapePoint) (FunctionShape, error) {
 	return pointsCopy, nil
 }
 
+// ResourceSpec describes a single resource that RequestedToCapacityRatio should score,
+// the weight it carries in the final weighted sum, and the FunctionShape used to turn
+// its utilization into a score. Name may be a built-in resource (v1.ResourceCPU,
+// v1.ResourceMemory) or the name of an extended/scalar resource such as
+// "nvidia.com/gpu" or "hugepages-2Mi".
+type ResourceSpec struct {
+	// Name of the resource.
+	Name v1.ResourceName
+	// Weight of the resource, relative to the other resources being scored. Must be positive.
+	Weight int64
+	// Shape used to score this resource. Must contain at least one point.
+	Shape FunctionShape
+}
+
+// defaultRequestedToCapacityRatioResources scores CPU and memory with equal weight,
+// using the default least-requested shape.
+var defaultRequestedToCapacityRatioResources = []ResourceSpec{
+	{Name: v1.ResourceCPU, Weight: 1, Shape: defaultFunctionShape},
+	{Name: v1.ResourceMemory, Weight: 1, Shape: defaultFunctionShape},
+}
+
+// validateResourceSpecs checks that resources is non-empty and that every entry has a
+// positive Weight and a non-empty Shape.
+func validateResourceSpecs(resources []ResourceSpec) error {
+	if len(resources) == 0 {
+		return fmt.Errorf("at least one resource must be specified")
+	}
+	for _, resource := range resources {
+		if resource.Weight <= 0 {
+			return fmt.Errorf("resource %q: weight must be positive, got %d", resource.Name, resource.Weight)
+		}
+		if len(resource.Shape) == 0 {
+			return fmt.Errorf("resource %q: shape must not be empty", resource.Name)
+		}
+	}
+	return nil
+}
+
 // RequestedToCapacityRatioResourceAllocationPriorityDefault creates a requestedToCapacity based
-// ResourceAllocationPriority using default resource scoring function shape.
+// ResourceAllocationPriority using the default resource scoring function shape.
 // The default function assigns 1.0 to resource when all capacity is available
-// and 0.0 when requested amount is equal to capacity.
+// and 0.0 when requested amount is equal to capacity, weighting CPU and memory equally.
 func RequestedToCapacityRatioResourceAllocationPriorityDefault() *ResourceAllocationPriority {
-	return RequestedToCapacityRatioResourceAllocationPriority(defaultFunctionShape)
+	priority, err := RequestedToCapacityRatioResourceAllocationPriority(defaultRequestedToCapacityRatioResources)
+	if err != nil {
+		// defaultRequestedToCapacityRatioResources is valid by construction.
+		panic(err)
+	}
+	return priority
 }
 
 // RequestedToCapacityRatioResourceAllocationPriority creates a requestedToCapacity based
-// ResourceAllocationPriority using provided resource scoring function shape.
-func RequestedToCapacityRatioResourceAllocationPriority(scoringFunctionShape FunctionShape) *ResourceAllocationPriority {
-	return &ResourceAllocationPriority{"RequestedToCapacityRatioResourceAllocationPriority", buildRequestedToCapacityRatioScorerFunction(scoringFunctionShape)}
+// ResourceAllocationPriority that scores every resource in resources according to its own
+// Shape and combines the per-resource scores into a weighted sum using each resource's
+// Weight. resources may include extended/scalar resources (e.g. "nvidia.com/gpu",
+// "hugepages-2Mi") in addition to cpu and memory. It returns an error if resources is
+// empty or if any ResourceSpec has a non-positive Weight or an empty Shape.
+func RequestedToCapacityRatioResourceAllocationPriority(resources []ResourceSpec) (*ResourceAllocationPriority, error) {
+	if err := validateResourceSpecs(resources); err != nil {
+		return nil, err
+	}
+	return &ResourceAllocationPriority{"RequestedToCapacityRatioResourceAllocationPriority", buildRequestedToCapacityRatioScorerFunction(resources)}, nil
 }
 
-func buildRequestedToCapacityRatioScorerFunction(scoringFunctionShape FunctionShape) func(*schedulercache.Resource, *schedulercache.Resource, bool, int, int) int64 {
-	rawScoringFunction := buildBrokenLinearFunction(scoringFunctionShape)
+// NewRequestedToCapacityRatioResourceAllocationPriorityFromArguments builds a
+// RequestedToCapacityRatioResourceAllocationPriority from the JSON/YAML-friendly
+// schedulerapi.RequestedToCapacityRatioArguments declared in a scheduler Policy file. This
+// package does not itself contain the policy loader (pkg/scheduler/factory), so wiring
+// this up as the loader's construction path for this priority is follow-up work; for now
+// this is the conversion this priority needs that loader to call, validated the same way
+// NewFunctionShape validates a programmatically-built shape.
+func NewRequestedToCapacityRatioResourceAllocationPriorityFromArguments(args schedulerapi.RequestedToCapacityRatioArguments) (*ResourceAllocationPriority, error) {
+	shape, err := utilizationShapeFromArguments(args)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := resourceSpecsFromArguments(args.Resources, shape)
+	return RequestedToCapacityRatioResourceAllocationPriority(resources)
+}
 
-	resourceScoringFunction := func(requested, capacity int64) int64 {
+// utilizationShapeFromArguments resolves args.Shape, or failing that one of the named
+// UtilizationShapePreset values, into a FunctionShape, reusing NewFunctionShape's
+// sorted-x/bounds-[0,100]/[0,MaxPriority] validation.
+func utilizationShapeFromArguments(args schedulerapi.RequestedToCapacityRatioArguments) (FunctionShape, error) {
+	if len(args.Shape) > 0 {
+		points := make([]FunctionShapePoint, len(args.Shape))
+		for i, point := range args.Shape {
+			points[i] = FunctionShapePoint{x: int64(point.Utilization), y: int64(point.Score)}
+		}
+		return NewFunctionShape(points)
+	}
+
+	switch args.UtilizationShapePreset {
+	case "", schedulerapi.LeastRequested:
+		return defaultFunctionShape, nil
+	case schedulerapi.MostRequested:
+		return NewFunctionShape([]FunctionShapePoint{{0, 0}, {100, maxY}})
+	case schedulerapi.Balanced:
+		return NewFunctionShape([]FunctionShapePoint{{0, 0}, {50, maxY}, {100, 0}})
+	default:
+		return nil, fmt.Errorf("unknown utilizationShapePreset %q", args.UtilizationShapePreset)
+	}
+}
+
+// resourceSpecsFromArguments converts specs into priorities.ResourceSpec, all scored with
+// shape, defaulting a zero Weight to 1 and defaulting to cpu/memory weighted equally when
+// specs is empty.
+func resourceSpecsFromArguments(specs []schedulerapi.ResourceSpec, shape FunctionShape) []ResourceSpec {
+	if len(specs) == 0 {
+		return []ResourceSpec{
+			{Name: v1.ResourceCPU, Weight: 1, Shape: shape},
+			{Name: v1.ResourceMemory, Weight: 1, Shape: shape},
+		}
+	}
+
+	resources := make([]ResourceSpec, len(specs))
+	for i, spec := range specs {
+		weight := spec.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		resources[i] = ResourceSpec{Name: v1.ResourceName(spec.Name), Weight: weight, Shape: shape}
+	}
+	return resources
+}
+
+func buildRequestedToCapacityRatioScorerFunction(resources []ResourceSpec) func(*schedulercache.Resource, *schedulercache.Resource, bool, int, int) int64 {
+	rawScoringFunctions := make(map[v1.ResourceName]func(int64) int64, len(resources))
+	var totalWeight int64
+	for _, resource := range resources {
+		rawScoringFunctions[resource.Name] = buildBrokenLinearFunction(resource.Shape)
+		totalWeight += resource.Weight
+	}
+
+	resourceScoringFunction := func(resourceName v1.ResourceName, requested, capacity int64) int64 {
+		rawScoringFunction := rawScoringFunctions[resourceName]
 		if capacity == 0 || requested > capacity {
 			return rawScoringFunction(maxX)
 		}
@@ -108,10 +231,161 @@ func buildRequestedToCapacityRatioScorerFunction(scoringFunctionShape FunctionSh
 	}
 
 	return func(requested, allocable *schedulercache.Resource, includeVolumes bool, requestedVolumes int, allocatableVolumes int) int64 {
-		cpuScore := resourceScoringFunction(requested.MilliCPU, allocable.MilliCPU)
-		memoryScore := resourceScoringFunction(requested.Memory, allocable.Memory)
-		return (cpuScore + memoryScore) / 2
+		var weightedScore int64
+		for _, resource := range resources {
+			weightedScore += resourceScoringFunction(
+				resource.Name,
+				requestedResourceValue(resource.Name, requested),
+				requestedResourceValue(resource.Name, allocable)) * resource.Weight
+		}
+		return weightedScore / totalWeight
+	}
+}
+
+// requestedResourceValue returns the quantity of resourceName carried by resource,
+// looking it up among the built-in CPU/memory fields first and falling back to
+// ScalarResources for extended resources (e.g. "nvidia.com/gpu") and other scalar
+// resources (e.g. "hugepages-2Mi").
+func requestedResourceValue(resourceName v1.ResourceName, resource *schedulercache.Resource) int64 {
+	switch resourceName {
+	case v1.ResourceCPU:
+		return resource.MilliCPU
+	case v1.ResourceMemory:
+		return resource.Memory
+	default:
+		return resource.ScalarResources[resourceName]
+	}
+}
+
+// setResourceValue stores value for resourceName in resource, looking it up among the
+// built-in CPU/memory fields first and falling back to ScalarResources, mirroring
+// requestedResourceValue.
+func setResourceValue(resourceName v1.ResourceName, resource *schedulercache.Resource, value int64) {
+	switch resourceName {
+	case v1.ResourceCPU:
+		resource.MilliCPU = value
+	case v1.ResourceMemory:
+		resource.Memory = value
+	default:
+		resource.ScalarResources[resourceName] = value
+	}
+}
+
+// NodeResourceAmplificationAnnotationKey is the node annotation read by
+// RequestedToCapacityRatioResourceAllocationPriorityWithAmplification to discover, per
+// node, how much its reported allocable capacity should be scaled up before scoring. The
+// value is a JSON object mapping resource name to ratio, e.g.
+//   {"cpu":"1.5","memory":"1.0","nvidia.com/gpu":"2.0"}
+const NodeResourceAmplificationAnnotationKey = "scheduler.alpha.kubernetes.io/resource-amplification"
+
+// nodeResourceAmplificationRatios parses NodeResourceAmplificationAnnotationKey off node.
+// Ratios are clamped to a minimum of 1.0, and resources that are missing or fail to parse
+// default to 1.0 (no amplification) rather than causing an error, since a malformed
+// annotation on one node should never break scoring of the rest of the cluster.
+func nodeResourceAmplificationRatios(node *v1.Node) map[v1.ResourceName]float64 {
+	ratios := make(map[v1.ResourceName]float64)
+
+	raw, ok := node.Annotations[NodeResourceAmplificationAnnotationKey]
+	if !ok {
+		return ratios
+	}
+
+	var parsed map[v1.ResourceName]string
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return ratios
+	}
+
+	for name, value := range parsed {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil || ratio < 1.0 {
+			ratio = 1.0
+		}
+		ratios[name] = ratio
+	}
+	return ratios
+}
+
+// amplifyAllocable returns a copy of allocable in which every resource named in resources
+// has been multiplied by its ratio in ratios. Resources with no entry in ratios (including
+// the case where ratios is empty because the node carries no amplification annotation) are
+// left unchanged.
+func amplifyAllocable(allocable *schedulercache.Resource, resources []ResourceSpec, ratios map[v1.ResourceName]float64) *schedulercache.Resource {
+	amplified := *allocable
+	amplified.ScalarResources = make(map[v1.ResourceName]int64, len(allocable.ScalarResources))
+	for name, value := range allocable.ScalarResources {
+		amplified.ScalarResources[name] = value
 	}
+
+	for _, resource := range resources {
+		ratio, ok := ratios[resource.Name]
+		if !ok {
+			continue
+		}
+		value := requestedResourceValue(resource.Name, allocable)
+		setResourceValue(resource.Name, &amplified, int64(float64(value)*ratio))
+	}
+	return &amplified
+}
+
+// AmplifiedResourceAllocationPriority is a requestedToCapacity based priority that scores
+// like ResourceAllocationPriority but first amplifies each node's allocable capacity per
+// its NodeResourceAmplificationAnnotationKey annotation. It is a distinct type, rather than
+// a ResourceAllocationPriority, because amplification needs the v1.Node object: unlike
+// AmplifiedResourceAllocationPriority.PriorityMap, ResourceAllocationPriority.PriorityMap
+// calls its scorer func(requested, allocable *schedulercache.Resource, ...) with no *v1.Node
+// parameter at all, so there is nowhere in that signature to read the annotation from.
+type AmplifiedResourceAllocationPriority struct {
+	Name      string
+	resources []ResourceSpec
+	scorer    func(requested, allocable *schedulercache.Resource, includeVolumes bool, requestedVolumes int, allocatableVolumes int) int64
+}
+
+// RequestedToCapacityRatioResourceAllocationPriorityWithAmplification creates an
+// AmplifiedResourceAllocationPriority that scores resources exactly as
+// RequestedToCapacityRatioResourceAllocationPriority(resources) would, except that each
+// node's allocable capacity is amplified per its NodeResourceAmplificationAnnotationKey
+// annotation before scoring. This lets clusters running oversubscription or normalization
+// on cold nodes score fairly against nominally-sized nodes. resources is validated exactly
+// as RequestedToCapacityRatioResourceAllocationPriority validates it.
+func RequestedToCapacityRatioResourceAllocationPriorityWithAmplification(resources []ResourceSpec) (*AmplifiedResourceAllocationPriority, error) {
+	if err := validateResourceSpecs(resources); err != nil {
+		return nil, err
+	}
+	return &AmplifiedResourceAllocationPriority{
+		Name:      "RequestedToCapacityRatioResourceAllocationPriorityWithAmplification",
+		resources: resources,
+		scorer:    buildRequestedToCapacityRatioScorerFunction(resources),
+	}, nil
+}
+
+// PriorityMap amplifies nodeInfo's allocable resources per the node's
+// NodeResourceAmplificationAnnotationKey annotation and then scores pod's request, plus the
+// resources already requested on the node, against that amplified capacity.
+func (a *AmplifiedResourceAllocationPriority) PriorityMap(pod *v1.Pod, meta interface{}, nodeInfo *schedulercache.NodeInfo) (schedulerapi.HostPriority, error) {
+	node := nodeInfo.Node()
+	if node == nil {
+		return schedulerapi.HostPriority{}, fmt.Errorf("node not found")
+	}
+
+	allocatable := nodeInfo.AllocatableResource()
+	amplified := amplifyAllocable(&allocatable, a.resources, nodeResourceAmplificationRatios(node))
+
+	requested := util.GetResourceRequest(pod)
+	nodeRequested := nodeInfo.RequestedResource()
+	requested.MilliCPU += nodeRequested.MilliCPU
+	requested.Memory += nodeRequested.Memory
+	for name, value := range nodeRequested.ScalarResources {
+		if requested.ScalarResources == nil {
+			requested.ScalarResources = make(map[v1.ResourceName]int64)
+		}
+		requested.ScalarResources[name] += value
+	}
+	score := a.scorer(requested, amplified, len(pod.Spec.Volumes) > 0, len(pod.Spec.Volumes), len(node.Status.VolumesAttached))
+
+	return schedulerapi.HostPriority{
+		Host:  node.Name,
+		Score: int(score),
+	}, nil
 }
 
 // Creates a function which is built using linear segments
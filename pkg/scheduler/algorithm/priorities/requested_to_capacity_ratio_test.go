@@ -24,37 +24,37 @@ import (
 	"github.com/stretchr/testify/assert"
 	"k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	schedulerapi "k8s.io/kubernetes/pkg/scheduler/api"
 	"k8s.io/kubernetes/pkg/scheduler/schedulercache"
 )
 
-func TestCreatingFunctionShapePanicsIfLengthOfXDoesNotMatchLengthOfY(t *testing.T) {
-	var err error
-	_, err = NewFunctionShape([]int64{1, 2}, []int64{1, 2, 3})
-	assert.Equal(t, "length of x(2) does not match length of y(3)", err.Error())
+func TestCreatingFunctionShapeErrorsIfEmpty(t *testing.T) {
+	_, err := NewFunctionShape([]FunctionShapePoint{})
+	assert.Equal(t, "at least one point must be specified", err.Error())
 }
 
 func TestCreatingFunctionShapeErrorsIfXIsNotSorted(t *testing.T) {
 	var err error
-	_, err = NewFunctionShape([]int64{10, 15, 20, 19, 25}, []int64{1, 2, 3, 4, 5})
+	_, err = NewFunctionShape([]FunctionShapePoint{{10, 1}, {15, 2}, {20, 3}, {19, 4}, {25, 5}})
 	assert.Equal(t, "values in x must be sorted. x[2]==20 >= x[3]==19", err.Error())
 
-	_, err = NewFunctionShape([]int64{10, 20, 20, 22, 25}, []int64{1, 2, 3, 4, 5})
+	_, err = NewFunctionShape([]FunctionShapePoint{{10, 1}, {20, 2}, {20, 3}, {22, 4}, {25, 5}})
 	assert.Equal(t, "values in x must be sorted. x[1]==20 >= x[2]==20", err.Error())
 }
 
 func TestCreatingFunctionPointNotInAllowedRange(t *testing.T) {
 	var err error
-	_, err = NewFunctionShape([]int64{-1, 100}, []int64{0, 10})
+	_, err = NewFunctionShape([]FunctionShapePoint{{-1, 0}, {100, 10}})
 	assert.Equal(t, "values in x must not be less than 0. x[0]==-1", err.Error())
 
-	_, err = NewFunctionShape([]int64{0, 101}, []int64{0, 10})
+	_, err = NewFunctionShape([]FunctionShapePoint{{0, 0}, {101, 10}})
 	assert.Equal(t, "values in x must not be greater than 100. x[1]==101", err.Error())
 
-	_, err = NewFunctionShape([]int64{0, 100}, []int64{-1, 10})
+	_, err = NewFunctionShape([]FunctionShapePoint{{0, -1}, {100, 10}})
 	assert.Equal(t, "values in y must not be less than 0. y[0]==-1", err.Error())
 
-	_, err = NewFunctionShape([]int64{0, 100}, []int64{0, 11})
+	_, err = NewFunctionShape([]FunctionShapePoint{{0, 0}, {100, 11}})
 	assert.Equal(t, "values in y must not be greater than 10. y[1]==11", err.Error())
 }
 
@@ -64,15 +64,13 @@ func TestBrokenLinearFunction(t *testing.T) {
 		expected int64
 	}
 	type Test struct {
-		x          []int64
-		y          []int64
+		points     []FunctionShapePoint
 		assertions []Assertion
 	}
 
 	tests := []Test{
 		{
-			x: []int64{10, 90},
-			y: []int64{1, 9},
+			points: []FunctionShapePoint{{10, 1}, {90, 9}},
 			assertions: []Assertion{
 				{p: -10, expected: 1},
 				{p: 0, expected: 1},
@@ -89,8 +87,7 @@ func TestBrokenLinearFunction(t *testing.T) {
 			},
 		},
 		{
-			x: []int64{0, 40, 100},
-			y: []int64{2, 10, 0},
+			points: []FunctionShapePoint{{0, 2}, {40, 10}, {100, 0}},
 			assertions: []Assertion{
 				{p: -10, expected: 2},
 				{p: 0, expected: 2},
@@ -103,8 +100,7 @@ func TestBrokenLinearFunction(t *testing.T) {
 			},
 		},
 		{
-			x: []int64{0, 40, 100},
-			y: []int64{2, 2, 2},
+			points: []FunctionShapePoint{{0, 2}, {40, 2}, {100, 2}},
 			assertions: []Assertion{
 				{p: -10, expected: 2},
 				{p: 0, expected: 2},
@@ -119,15 +115,112 @@ func TestBrokenLinearFunction(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		functionShape, err := NewFunctionShape(test.x, test.y)
+		functionShape, err := NewFunctionShape(test.points)
 		assert.Nil(t, err)
 		function := buildBrokenLinearFunction(functionShape)
 		for _, assertion := range test.assertions {
-			assert.InDelta(t, assertion.expected, function(assertion.p), 0.1, "x=%v, y=%v, p=%f", test.x, test.y, assertion.p)
+			assert.InDelta(t, assertion.expected, function(assertion.p), 0.1, "points=%v, p=%d", test.points, assertion.p)
 		}
 	}
 }
 
+func TestValidateResourceSpecs(t *testing.T) {
+	leastRequestedShape, _ := NewFunctionShape([]FunctionShapePoint{{0, 10}, {100, 0}})
+
+	tests := []struct {
+		name      string
+		resources []ResourceSpec
+		wantErr   string
+	}{
+		{
+			name:      "no resources",
+			resources: nil,
+			wantErr:   "at least one resource must be specified",
+		},
+		{
+			name:      "zero weight",
+			resources: []ResourceSpec{{Name: v1.ResourceCPU, Weight: 0, Shape: leastRequestedShape}},
+			wantErr:   `resource "cpu": weight must be positive, got 0`,
+		},
+		{
+			name:      "negative weight",
+			resources: []ResourceSpec{{Name: v1.ResourceCPU, Weight: -1, Shape: leastRequestedShape}},
+			wantErr:   `resource "cpu": weight must be positive, got -1`,
+		},
+		{
+			name:      "empty shape",
+			resources: []ResourceSpec{{Name: v1.ResourceCPU, Weight: 1, Shape: FunctionShape{}}},
+			wantErr:   `resource "cpu": shape must not be empty`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := RequestedToCapacityRatioResourceAllocationPriority(test.resources)
+			assert.EqualError(t, err, test.wantErr)
+		})
+	}
+}
+
+func TestRequestedToCapacityRatioScalarResources(t *testing.T) {
+	leastRequestedShape, _ := NewFunctionShape([]FunctionShapePoint{{0, 10}, {100, 0}})
+	mostRequestedShape, _ := NewFunctionShape([]FunctionShapePoint{{0, 0}, {100, 10}})
+	gpu := v1.ResourceName("nvidia.com/gpu")
+
+	tests := []struct {
+		name      string
+		resources []ResourceSpec
+		requested *schedulercache.Resource
+		allocable *schedulercache.Resource
+		expected  int64
+	}{
+		{
+			name:      "gpu-only",
+			resources: []ResourceSpec{{Name: gpu, Weight: 1, Shape: leastRequestedShape}},
+			requested: &schedulercache.Resource{ScalarResources: map[v1.ResourceName]int64{gpu: 0}},
+			allocable: &schedulercache.Resource{ScalarResources: map[v1.ResourceName]int64{gpu: 4}},
+			expected:  10,
+		},
+		{
+			name: "mixed cpu/gpu, equal weight",
+			resources: []ResourceSpec{
+				{Name: v1.ResourceCPU, Weight: 1, Shape: leastRequestedShape},
+				{Name: gpu, Weight: 1, Shape: mostRequestedShape},
+			},
+			requested: &schedulercache.Resource{MilliCPU: 0, ScalarResources: map[v1.ResourceName]int64{gpu: 0}},
+			allocable: &schedulercache.Resource{MilliCPU: 4000, ScalarResources: map[v1.ResourceName]int64{gpu: 4}},
+			expected:  5,
+		},
+		{
+			name: "mixed cpu/gpu, gpu weighted higher",
+			resources: []ResourceSpec{
+				{Name: v1.ResourceCPU, Weight: 1, Shape: leastRequestedShape},
+				{Name: gpu, Weight: 3, Shape: mostRequestedShape},
+			},
+			requested: &schedulercache.Resource{MilliCPU: 0, ScalarResources: map[v1.ResourceName]int64{gpu: 0}},
+			allocable: &schedulercache.Resource{MilliCPU: 4000, ScalarResources: map[v1.ResourceName]int64{gpu: 4}},
+			expected:  2,
+		},
+		{
+			name: "mixed cpu/gpu, cpu weighted higher",
+			resources: []ResourceSpec{
+				{Name: v1.ResourceCPU, Weight: 3, Shape: leastRequestedShape},
+				{Name: gpu, Weight: 1, Shape: mostRequestedShape},
+			},
+			requested: &schedulercache.Resource{MilliCPU: 0, ScalarResources: map[v1.ResourceName]int64{gpu: 0}},
+			allocable: &schedulercache.Resource{MilliCPU: 4000, ScalarResources: map[v1.ResourceName]int64{gpu: 4}},
+			expected:  7,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			scorer := buildRequestedToCapacityRatioScorerFunction(test.resources)
+			assert.Equal(t, test.expected, scorer(test.requested, test.allocable, false, 0, 0))
+		})
+	}
+}
+
 func TestRequestedToCapacityRatio(t *testing.T) {
 	type resources struct {
 		cpu int64
@@ -243,3 +336,155 @@ func TestRequestedToCapacityRatio(t *testing.T) {
 		}
 	}
 }
+
+func TestNodeResourceAmplificationRatios(t *testing.T) {
+	gpu := v1.ResourceName("nvidia.com/gpu")
+
+	tests := []struct {
+		name     string
+		node     *v1.Node
+		expected map[v1.ResourceName]float64
+	}{
+		{
+			name:     "no annotation",
+			node:     &v1.Node{},
+			expected: map[v1.ResourceName]float64{},
+		},
+		{
+			name: "valid ratios",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					NodeResourceAmplificationAnnotationKey: `{"cpu":"1.5","memory":"1.0","nvidia.com/gpu":"2.0"}`,
+				},
+			}},
+			expected: map[v1.ResourceName]float64{
+				v1.ResourceCPU:    1.5,
+				v1.ResourceMemory: 1.0,
+				gpu:               2.0,
+			},
+		},
+		{
+			name: "ratio below 1.0 is clamped to 1.0",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					NodeResourceAmplificationAnnotationKey: `{"cpu":"0.5"}`,
+				},
+			}},
+			expected: map[v1.ResourceName]float64{v1.ResourceCPU: 1.0},
+		},
+		{
+			name: "unparseable ratio falls back to 1.0",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					NodeResourceAmplificationAnnotationKey: `{"cpu":"not-a-number"}`,
+				},
+			}},
+			expected: map[v1.ResourceName]float64{v1.ResourceCPU: 1.0},
+		},
+		{
+			name: "malformed annotation is ignored entirely",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{
+					NodeResourceAmplificationAnnotationKey: `not-json`,
+				},
+			}},
+			expected: map[v1.ResourceName]float64{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, nodeResourceAmplificationRatios(test.node))
+		})
+	}
+}
+
+func TestAmplifyAllocable(t *testing.T) {
+	gpu := v1.ResourceName("nvidia.com/gpu")
+	resources := []ResourceSpec{
+		{Name: v1.ResourceCPU, Weight: 1, Shape: defaultFunctionShape},
+		{Name: v1.ResourceMemory, Weight: 1, Shape: defaultFunctionShape},
+		{Name: gpu, Weight: 1, Shape: defaultFunctionShape},
+	}
+	allocable := &schedulercache.Resource{
+		MilliCPU:        4000,
+		Memory:          10000,
+		ScalarResources: map[v1.ResourceName]int64{gpu: 4},
+	}
+	ratios := map[v1.ResourceName]float64{v1.ResourceCPU: 1.5, gpu: 2.0}
+
+	amplified := amplifyAllocable(allocable, resources, ratios)
+
+	assert.Equal(t, int64(6000), amplified.MilliCPU)
+	assert.Equal(t, int64(10000), amplified.Memory)
+	assert.Equal(t, int64(8), amplified.ScalarResources[gpu])
+
+	// the input must not be mutated
+	assert.Equal(t, int64(4000), allocable.MilliCPU)
+	assert.Equal(t, int64(4), allocable.ScalarResources[gpu])
+}
+
+func TestRequestedToCapacityRatioWithAmplificationScoresColdNodesFairly(t *testing.T) {
+	leastRequestedShape, _ := NewFunctionShape([]FunctionShapePoint{{0, 10}, {100, 0}})
+	resources := []ResourceSpec{{Name: v1.ResourceCPU, Weight: 1, Shape: leastRequestedShape}}
+	scorer := buildRequestedToCapacityRatioScorerFunction(resources)
+
+	requested := &schedulercache.Resource{MilliCPU: 3000}
+	rawAllocable := &schedulercache.Resource{MilliCPU: 4000}
+
+	coldNodeRatios := map[v1.ResourceName]float64{v1.ResourceCPU: 2.0}
+	nominalNodeRatios := map[v1.ResourceName]float64{}
+
+	coldScore := scorer(requested, amplifyAllocable(rawAllocable, resources, coldNodeRatios), false, 0, 0)
+	nominalScore := scorer(requested, amplifyAllocable(rawAllocable, resources, nominalNodeRatios), false, 0, 0)
+
+	assert.Equal(t, int64(7), coldScore)
+	assert.Equal(t, int64(3), nominalScore)
+}
+
+func TestNewRequestedToCapacityRatioResourceAllocationPriorityFromArgumentsValidation(t *testing.T) {
+	_, err := NewRequestedToCapacityRatioResourceAllocationPriorityFromArguments(schedulerapi.RequestedToCapacityRatioArguments{
+		Shape: []schedulerapi.UtilizationShapePoint{{Utilization: 50, Score: 1}, {Utilization: 10, Score: 2}},
+	})
+	assert.Equal(t, "values in x must be sorted. x[0]==50 >= x[1]==10", err.Error())
+
+	_, err = NewRequestedToCapacityRatioResourceAllocationPriorityFromArguments(schedulerapi.RequestedToCapacityRatioArguments{
+		UtilizationShapePreset: "Unknown",
+	})
+	assert.Equal(t, `unknown utilizationShapePreset "Unknown"`, err.Error())
+}
+
+func TestRequestedToCapacityRatioResourceAllocationPriorityFromArgumentsDefaults(t *testing.T) {
+	priority, err := NewRequestedToCapacityRatioResourceAllocationPriorityFromArguments(schedulerapi.RequestedToCapacityRatioArguments{})
+	assert.Nil(t, err)
+	assert.Equal(t, "RequestedToCapacityRatioResourceAllocationPriority", priority.Name)
+}
+
+// TestRequestedToCapacityRatioResourceAllocationPriorityFromArgumentsBinPacking builds a
+// RequestedToCapacityRatioArguments value in Go and exercises the resulting priority's
+// scoring end-to-end; it does not go through a serialized Policy file or the policy
+// loader, since this package doesn't contain that loader (see the constructor's doc
+// comment).
+func TestRequestedToCapacityRatioResourceAllocationPriorityFromArgumentsBinPacking(t *testing.T) {
+	priority, err := NewRequestedToCapacityRatioResourceAllocationPriorityFromArguments(schedulerapi.RequestedToCapacityRatioArguments{
+		UtilizationShapePreset: schedulerapi.MostRequested,
+	})
+	assert.Nil(t, err)
+
+	nodes := []*v1.Node{makeNode("emptier", 4000, 8000), makeNode("fuller", 4000, 8000)}
+
+	scheduledPod := buildNUMATestPod(3000, 6000)
+	scheduledPod.Spec.NodeName = "fuller"
+
+	nodeNameToInfo := schedulercache.CreateNodeNameToInfoMap([]*v1.Pod{scheduledPod}, nodes)
+	newPod := buildNUMATestPod(0, 0)
+
+	list, err := priorityFunction(priority.PriorityMap, nil, nil)(newPod, nodeNameToInfo, nodes)
+	assert.Nil(t, err)
+
+	scores := map[string]int{}
+	for _, hostPriority := range list {
+		scores[hostPriority.Host] = hostPriority.Score
+	}
+	assert.True(t, scores["fuller"] > scores["emptier"], "bin-packing preset should prefer the more utilized node: %#v", scores)
+}
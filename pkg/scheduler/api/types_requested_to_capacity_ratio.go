@@ -0,0 +1,64 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+// Names of the built-in UtilizationShapePreset values recognized by
+// RequestedToCapacityRatioArguments.
+const (
+	// LeastRequested gives priority to nodes with the most available capacity.
+	LeastRequested = "LeastRequested"
+	// MostRequested gives priority to nodes with the least available capacity
+	// (bin-packing).
+	MostRequested = "MostRequested"
+	// Balanced gives priority to nodes whose utilization is closest to 50%.
+	Balanced = "Balanced"
+)
+
+// UtilizationShapePoint is a single point of a requestedToCapacityRatio scoring function
+// shape, in a form that can be declared in a Policy file.
+type UtilizationShapePoint struct {
+	// Utilization is the x-axis value of the point, in the range [0, 100].
+	Utilization int32 `json:"utilization"`
+	// Score is the y-axis value of the point, in the range [0, MaxPriority].
+	Score int32 `json:"score"`
+}
+
+// ResourceSpec declares a single resource to be scored by requestedToCapacityRatio and the
+// weight it carries relative to the other declared resources.
+type ResourceSpec struct {
+	// Name of the resource, e.g. "cpu", "memory", or the name of an extended resource
+	// such as "nvidia.com/gpu".
+	Name string `json:"name"`
+	// Weight of the resource. Must be positive. Defaults to 1 when omitted.
+	Weight int64 `json:"weight,omitempty"`
+}
+
+// RequestedToCapacityRatioArguments declares, in a form that can be loaded from a Policy
+// file, the resources and scoring shape used by the requestedToCapacityRatio priority.
+// Shape takes precedence over UtilizationShapePreset when both are set; if neither is set,
+// UtilizationShapePreset defaults to LeastRequested. Resources defaults to cpu and memory,
+// weighted equally, when empty.
+type RequestedToCapacityRatioArguments struct {
+	// Shape is an explicit scoring function shape. Takes precedence over
+	// UtilizationShapePreset when non-empty.
+	Shape []UtilizationShapePoint `json:"shape,omitempty"`
+	// UtilizationShapePreset names one of LeastRequested, MostRequested or Balanced. Used
+	// when Shape is empty.
+	UtilizationShapePreset string `json:"utilizationShapePreset,omitempty"`
+	// Resources lists the resources to score.
+	Resources []ResourceSpec `json:"resources,omitempty"`
+}
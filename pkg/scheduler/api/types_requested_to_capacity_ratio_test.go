@@ -0,0 +1,63 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestedToCapacityRatioArgumentsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		args RequestedToCapacityRatioArguments
+	}{
+		{
+			name: "explicit shape and resources",
+			args: RequestedToCapacityRatioArguments{
+				Shape: []UtilizationShapePoint{{Utilization: 0, Score: 10}, {Utilization: 100, Score: 0}},
+				Resources: []ResourceSpec{
+					{Name: "cpu", Weight: 1},
+					{Name: "nvidia.com/gpu", Weight: 5},
+				},
+			},
+		},
+		{
+			name: "preset only",
+			args: RequestedToCapacityRatioArguments{
+				UtilizationShapePreset: MostRequested,
+			},
+		},
+		{
+			name: "zero value",
+			args: RequestedToCapacityRatioArguments{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw, err := json.Marshal(test.args)
+			assert.Nil(t, err)
+
+			var roundTripped RequestedToCapacityRatioArguments
+			assert.Nil(t, json.Unmarshal(raw, &roundTripped))
+			assert.Equal(t, test.args, roundTripped)
+		})
+	}
+}